@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// watchBackoffBase is the initial backoff applied after a failed
+	// DescribeSecret/render call in watch mode.
+	watchBackoffBase = 2 * time.Second
+	// watchBackoffMax caps the exponential backoff on repeated failures,
+	// most notably AWS throttling.
+	watchBackoffMax = 2 * time.Minute
+)
+
+// runWatch keeps the process alive, polling DescribeSecret for the
+// AWSCURRENT VersionId of every secret resolved from selector, and
+// re-renders the template whenever a version changes. It blocks until
+// SIGTERM/SIGINT is received. If failFastOnInitialRender is set, a failure
+// of the first render is returned immediately instead of being retried.
+func runWatch(selector secretSelector, directives map[string]*secretDirective, backends []SecretProvider, sess *session.Session, interval time.Duration, postRenderHook string, failFastOnInitialRender bool) error {
+	svc := secretsmanager.New(sess)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	versions, arns, err := renderAndTrackVersions(selector, directives, backends, sess, postRenderHook)
+	backoff := watchBackoffBase
+	for err != nil {
+		if failFastOnInitialRender {
+			return err
+		}
+		logrus.WithError(err).Warnf("Initial render failed, retrying in %s", backoff)
+		select {
+		case <-ctx.Done():
+			logrus.Info("Received shutdown signal, stopping watch")
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff < watchBackoffMax {
+			backoff *= 2
+		}
+		versions, arns, err = renderAndTrackVersions(selector, directives, backends, sess, postRenderHook)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	backoff = watchBackoffBase
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Received shutdown signal, stopping watch")
+			return nil
+		case <-ticker.C:
+			changed, newVersions, err := secretsRotated(ctx, svc, arns, versions)
+			if err != nil {
+				if isThrottlingError(err) {
+					logrus.WithError(err).Warnf("Throttled checking secret versions, backing off %s", backoff)
+					select {
+					case <-ctx.Done():
+						logrus.Info("Received shutdown signal, stopping watch")
+						return nil
+					case <-time.After(backoff):
+					}
+					if backoff < watchBackoffMax {
+						backoff *= 2
+					}
+					continue
+				}
+				logrus.WithError(err).Error("Error checking secret versions")
+				continue
+			}
+			backoff = watchBackoffBase
+
+			if !changed {
+				continue
+			}
+
+			logrus.Info("Detected secret rotation, re-rendering template")
+			newVersions, arns, err = renderAndTrackVersions(selector, directives, backends, sess, postRenderHook)
+			if err != nil {
+				logrus.WithError(err).Error("Error re-rendering template after rotation")
+				continue
+			}
+			versions = newVersions
+		}
+	}
+}
+
+// isThrottlingError reports whether err is an AWS throttling error, which
+// callers should back off on rather than treat as a hard failure.
+func isThrottlingError(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch awsErr.Code() {
+	case "ThrottlingException", "Throttling", "TooManyRequestsException":
+		return true
+	default:
+		return false
+	}
+}
+
+// renderAndTrackVersions resolves secrets across backends, writes the
+// template output, runs the post-render hook if set, and returns the
+// AWSCURRENT VersionId of every resolved secret backed by Secrets Manager
+// so the watch loop can detect future rotations. Refs from other backends
+// (SSM, Vault, pass) have no rotation signal this tool can poll; their
+// values are simply re-rendered whenever an AWS secret's version changes.
+// Non-AWS backends going undetected between rotations is an accepted
+// limitation of watch mode today.
+func renderAndTrackVersions(selector secretSelector, directives map[string]*secretDirective, backends []SecretProvider, sess *session.Session, postRenderHook string) (map[string]string, map[string]string, error) {
+	ctx := context.Background()
+
+	templateContext, refs, err := resolveSecretContext(ctx, backends, selector, directives)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := createFile(inputTemplateName, templateContext); err != nil {
+		return nil, nil, err
+	}
+	if postRenderHook != "" {
+		runPostRenderHook(postRenderHook)
+	}
+
+	arns := make(map[string]string)
+	for name, ref := range refs {
+		if ref.Backend == "aws" {
+			arns[name] = ref.ID
+		}
+	}
+
+	svc := secretsmanager.New(sess)
+	versions := make(map[string]string, len(arns))
+	for name, arn := range arns {
+		version, err := currentVersionID(ctx, svc, arn)
+		if err != nil {
+			return nil, nil, err
+		}
+		versions[name] = version
+	}
+	return versions, arns, nil
+}
+
+// currentVersionID returns the VersionId currently staged as AWSCURRENT
+// for the given secret ARN.
+func currentVersionID(ctx context.Context, svc *secretsmanager.SecretsManager, arn string) (string, error) {
+	result, err := svc.DescribeSecretWithContext(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(arn),
+	})
+	if err != nil {
+		return "", err
+	}
+	for versionID, stages := range result.VersionIdsToStages {
+		for _, stage := range stages {
+			if aws.StringValue(stage) == "AWSCURRENT" {
+				return versionID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no AWSCURRENT version found for secret %s", arn)
+}
+
+// secretsRotated compares the current AWSCURRENT VersionId of each ARN
+// against the last known versions and reports whether any changed.
+func secretsRotated(ctx context.Context, svc *secretsmanager.SecretsManager, arns map[string]string, versions map[string]string) (bool, map[string]string, error) {
+	newVersions := make(map[string]string, len(arns))
+	for name, arn := range arns {
+		version, err := currentVersionID(ctx, svc, arn)
+		if err != nil {
+			return false, nil, err
+		}
+		newVersions[name] = version
+	}
+	return versionsChanged(versions, newVersions), newVersions, nil
+}
+
+// versionsChanged reports whether any name in newVersions maps to a
+// different VersionId than it did in oldVersions, split out of
+// secretsRotated so the version-diffing logic can be unit tested without
+// an AWS client.
+func versionsChanged(oldVersions, newVersions map[string]string) bool {
+	for name, version := range newVersions {
+		if oldVersions[name] != version {
+			return true
+		}
+	}
+	return false
+}
+
+// runPostRenderHook executes the configured post-render hook command
+// (e.g. `systemctl reload nginx`) through the shell, logging but not
+// failing the watch loop if it errors.
+func runPostRenderHook(command string) {
+	logrus.WithField("command", command).Info("Running post-render hook")
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		logrus.WithField("command", command).WithError(err).Error("Post-render hook failed")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestIsThrottlingError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"non-AWS error", errors.New("boom"), false},
+		{"ThrottlingException", awserr.New("ThrottlingException", "slow down", nil), true},
+		{"Throttling", awserr.New("Throttling", "slow down", nil), true},
+		{"TooManyRequestsException", awserr.New("TooManyRequestsException", "slow down", nil), true},
+		{"unrelated AWS error", awserr.New("ResourceNotFoundException", "not found", nil), false},
+	}
+	for _, c := range cases {
+		if got := isThrottlingError(c.err); got != c.want {
+			t.Errorf("%s: isThrottlingError() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestVersionsChanged(t *testing.T) {
+	cases := []struct {
+		name    string
+		old     map[string]string
+		current map[string]string
+		want    bool
+	}{
+		{"identical versions", map[string]string{"db": "v1"}, map[string]string{"db": "v1"}, false},
+		{"one secret rotated", map[string]string{"db": "v1", "api": "v1"}, map[string]string{"db": "v2", "api": "v1"}, true},
+		{"new secret with no prior version", map[string]string{}, map[string]string{"db": "v1"}, true},
+		{"empty on both sides", map[string]string{}, map[string]string{}, false},
+	}
+	for _, c := range cases {
+		if got := versionsChanged(c.old, c.current); got != c.want {
+			t.Errorf("%s: versionsChanged() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
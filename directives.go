@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// secretDirectiveRe matches a `# secret: <name> key=value ...` comment line
+// in an input template, e.g.:
+//
+//	# secret: db_password stage=AWSPREVIOUS length=32
+var secretDirectiveRe = regexp.MustCompile(`^\s*#\s*secret:\s*(\S+)\s*(.*)$`)
+
+// secretDirective pins a single secret to a specific version/stage and,
+// optionally, an expected length so rotation-sensitive templates render
+// deterministically instead of always taking AWSCURRENT.
+type secretDirective struct {
+	// Name is the AWS secret name the directive applies to.
+	Name string
+	// Stage is a VersionStage such as AWSCURRENT, AWSPREVIOUS, or a custom
+	// staging label. Empty means "let AWS pick the default (AWSCURRENT)".
+	Stage string
+	// VersionID pins an exact VersionId, taking precedence over Stage.
+	VersionID string
+	// Length, if non-zero, is the expected length of the secret string;
+	// a mismatch is treated as a fatal configuration error.
+	Length int
+}
+
+// parseSecretDirectives scans a template file for `# secret: name key=value`
+// directive comments and returns them keyed by secret name.
+func parseSecretDirectives(templateFile string) (map[string]*secretDirective, error) {
+	file, err := os.Open(templateFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	directives := make(map[string]*secretDirective)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		matches := secretDirectiveRe.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+
+		d := &secretDirective{Name: matches[1]}
+		for _, field := range strings.Fields(matches[2]) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid secret directive field %q for %q", field, d.Name)
+			}
+			switch kv[0] {
+			case "stage":
+				d.Stage = kv[1]
+			case "version":
+				d.VersionID = kv[1]
+			case "length":
+				length, err := strconv.Atoi(kv[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid length in secret directive for %q: %w", d.Name, err)
+				}
+				d.Length = length
+			default:
+				return nil, fmt.Errorf("unknown secret directive field %q for %q", kv[0], d.Name)
+			}
+		}
+		directives[d.Name] = d
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return directives, nil
+}
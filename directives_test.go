@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempTemplate(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "template.tmpl")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing temp template: %v", err)
+	}
+	return path
+}
+
+func TestParseSecretDirectives(t *testing.T) {
+	path := writeTempTemplate(t, `
+# secret: db_password stage=AWSPREVIOUS length=32
+# secret: api_key version=abc123
+some non-directive line
+# secret: plain_name
+`)
+
+	directives, err := parseSecretDirectives(path)
+	if err != nil {
+		t.Fatalf("parseSecretDirectives: unexpected error: %v", err)
+	}
+
+	dbPassword, ok := directives["db_password"]
+	if !ok {
+		t.Fatal("expected a directive for db_password")
+	}
+	if dbPassword.Stage != "AWSPREVIOUS" || dbPassword.Length != 32 {
+		t.Errorf("db_password = %+v, want Stage=AWSPREVIOUS Length=32", dbPassword)
+	}
+
+	apiKey, ok := directives["api_key"]
+	if !ok {
+		t.Fatal("expected a directive for api_key")
+	}
+	if apiKey.VersionID != "abc123" {
+		t.Errorf("api_key.VersionID = %q, want abc123", apiKey.VersionID)
+	}
+
+	plain, ok := directives["plain_name"]
+	if !ok {
+		t.Fatal("expected a directive for plain_name")
+	}
+	if plain.Stage != "" || plain.VersionID != "" || plain.Length != 0 {
+		t.Errorf("plain_name = %+v, want all zero-value fields", plain)
+	}
+
+	if len(directives) != 3 {
+		t.Errorf("len(directives) = %d, want 3", len(directives))
+	}
+}
+
+func TestParseSecretDirectivesInvalidLength(t *testing.T) {
+	path := writeTempTemplate(t, "# secret: foo length=notanumber\n")
+	if _, err := parseSecretDirectives(path); err == nil {
+		t.Error("expected an error for a non-numeric length")
+	}
+}
+
+func TestParseSecretDirectivesUnknownField(t *testing.T) {
+	path := writeTempTemplate(t, "# secret: foo bogus=1\n")
+	if _, err := parseSecretDirectives(path); err == nil {
+		t.Error("expected an error for an unknown directive field")
+	}
+}
+
+func TestParseSecretDirectivesNoDirectives(t *testing.T) {
+	path := writeTempTemplate(t, "just a template, no directives here\n")
+	directives, err := parseSecretDirectives(path)
+	if err != nil {
+		t.Fatalf("parseSecretDirectives: unexpected error: %v", err)
+	}
+	if len(directives) != 0 {
+		t.Errorf("len(directives) = %d, want 0", len(directives))
+	}
+}
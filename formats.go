@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+)
+
+// Supported --format / OUTPUT_FORMAT values.
+const (
+	formatTemplate  = "template"
+	formatDotenv    = "dotenv"
+	formatJSON      = "json"
+	formatTOML      = "toml"
+	formatK8sSecret = "k8s-secret"
+)
+
+// createFile renders secrets to outputName using the format selected via
+// outputFormat (set from --format / OUTPUT_FORMAT in validateConfig).
+// templateFile is only consulted for formatTemplate.
+func createFile(templateFile string, secrets map[string]interface{}) error {
+	switch outputFormat {
+	case formatTemplate:
+		return renderTemplateFile(templateFile, secrets)
+	case formatDotenv:
+		return renderDotenv(secrets)
+	case formatJSON:
+		return renderJSON(secrets)
+	case formatTOML:
+		return renderTOML(secrets)
+	case formatK8sSecret:
+		return renderK8sSecret(secrets)
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+}
+
+// renderTemplateFile executes templateFile with text/template against
+// secrets, using templateFuncMap for Sprig and encoding helpers.
+// text/template is used rather than html/template since secret values are
+// config data, not HTML, and must not be escaped.
+func renderTemplateFile(templateFile string, secrets map[string]interface{}) error {
+	tmpl, err := template.New(filepath.Base(templateFile)).Funcs(templateFuncMap(secrets)).ParseFiles(templateFile)
+	if err != nil {
+		return fmt.Errorf("ParseFiles error for %q: %w", templateFile, err)
+	}
+
+	outputFile, err := os.Create(outputName)
+	if err != nil {
+		return fmt.Errorf("error creating output file %q: %w", outputName, err)
+	}
+	defer outputFile.Close()
+
+	if err := tmpl.Execute(outputFile, secrets); err != nil {
+		return fmt.Errorf("error executing template into %q: %w", outputName, err)
+	}
+
+	logrus.Infof("Template output has been written to: %s", outputName)
+	return nil
+}
+
+// renderDotenv writes secrets as shell-safe KEY=value lines. Non-string
+// values (nested objects/arrays preserved from secret JSON) are rendered
+// as their JSON encoding.
+func renderDotenv(secrets map[string]interface{}) error {
+	outputFile, err := os.Create(outputName)
+	if err != nil {
+		return fmt.Errorf("error creating output file %q: %w", outputName, err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	for _, key := range sortedKeys(secrets) {
+		fmt.Fprintf(writer, "%s=%s\n", key, shellQuote(stringifyValue(secrets[key])))
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing dotenv output to %q: %w", outputName, err)
+	}
+
+	logrus.Infof("Dotenv output has been written to: %s", outputName)
+	return nil
+}
+
+// shellQuote single-quotes value POSIX-shell style, so a dotenv file can be
+// safely `source`d even when values contain spaces, quotes, or `$`.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// stringifyValue renders a template-context value as a string: strings
+// pass through untouched, everything else (numbers, bools, nested
+// objects/arrays) is JSON-encoded.
+func stringifyValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// renderJSON writes secrets as a single JSON object, preserving nested
+// structure from parsed secret JSON.
+func renderJSON(secrets map[string]interface{}) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling secrets to JSON: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(outputName, data, 0o644); err != nil {
+		return fmt.Errorf("error writing JSON output to %q: %w", outputName, err)
+	}
+
+	logrus.Infof("JSON output has been written to: %s", outputName)
+	return nil
+}
+
+// renderTOML writes secrets as a single TOML table, preserving nested
+// structure from parsed secret JSON.
+func renderTOML(secrets map[string]interface{}) error {
+	outputFile, err := os.Create(outputName)
+	if err != nil {
+		return fmt.Errorf("error creating output file %q: %w", outputName, err)
+	}
+	defer outputFile.Close()
+
+	if err := toml.NewEncoder(outputFile).Encode(secrets); err != nil {
+		return fmt.Errorf("error encoding TOML output to %q: %w", outputName, err)
+	}
+
+	logrus.Infof("TOML output has been written to: %s", outputName)
+	return nil
+}
+
+// renderK8sSecret writes a ready-to-apply `kind: Secret` manifest, with
+// each value base64-encoded under data:, named via k8sSecretName /
+// k8sSecretNamespace.
+func renderK8sSecret(secrets map[string]interface{}) error {
+	outputFile, err := os.Create(outputName)
+	if err != nil {
+		return fmt.Errorf("error creating output file %q: %w", outputName, err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	fmt.Fprintln(writer, "apiVersion: v1")
+	fmt.Fprintln(writer, "kind: Secret")
+	fmt.Fprintln(writer, "metadata:")
+	fmt.Fprintf(writer, "  name: %s\n", k8sSecretName)
+	if k8sSecretNamespace != "" {
+		fmt.Fprintf(writer, "  namespace: %s\n", k8sSecretNamespace)
+	}
+	fmt.Fprintln(writer, "type: Opaque")
+	fmt.Fprintln(writer, "data:")
+	for _, key := range sortedKeys(secrets) {
+		fmt.Fprintf(writer, "  %s: %s\n", yamlQuoteKey(key), base64.StdEncoding.EncodeToString([]byte(stringifyValue(secrets[key]))))
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing k8s Secret manifest to %q: %w", outputName, err)
+	}
+
+	logrus.Infof("Kubernetes Secret manifest has been written to: %s", outputName)
+	return nil
+}
+
+// yamlQuoteKey renders key as a YAML double-quoted scalar so a secret's
+// JSON field name containing YAML-significant characters (a colon, a
+// leading "-", etc.) can't break or change the meaning of the manifest's
+// data: map. The value beside it is already base64, so only the key needs
+// this treatment.
+func yamlQuoteKey(key string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key)
+	return `"` + escaped + `"`
+}
+
+// sortedKeys returns m's keys in sorted order so rendered output is
+// deterministic across runs.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
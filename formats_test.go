@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withOutputName(t *testing.T, name string) {
+	t.Helper()
+	prev := outputName
+	outputName = name
+	t.Cleanup(func() { outputName = prev })
+}
+
+func TestStringifyValue(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		{"plain", "plain"},
+		{float64(42), "42"},
+		{true, "true"},
+		{map[string]interface{}{"a": float64(1)}, `{"a":1}`},
+	}
+	for _, c := range cases {
+		if got := stringifyValue(c.value); got != c.want {
+			t.Errorf("stringifyValue(%v) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	cases := []struct{ value, want string }{
+		{"plain", "'plain'"},
+		{"it's", `'it'\''s'`},
+		{"", "''"},
+	}
+	for _, c := range cases {
+		if got := shellQuote(c.value); got != c.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	got := sortedKeys(map[string]interface{}{"c": 1, "a": 1, "b": 1})
+	want := []string{"a", "b", "c"}
+	for i, k := range want {
+		if got[i] != k {
+			t.Errorf("sortedKeys()[%d] = %q, want %q", i, got[i], k)
+		}
+	}
+}
+
+func TestYamlQuoteKey(t *testing.T) {
+	cases := []struct{ key, want string }{
+		{"plain", `"plain"`},
+		{"has:colon", `"has:colon"`},
+		{`has"quote`, `"has\"quote"`},
+		{`has\backslash`, `"has\\backslash"`},
+	}
+	for _, c := range cases {
+		if got := yamlQuoteKey(c.key); got != c.want {
+			t.Errorf("yamlQuoteKey(%q) = %q, want %q", c.key, got, c.want)
+		}
+	}
+}
+
+func TestRenderDotenv(t *testing.T) {
+	withOutputName(t, filepath.Join(t.TempDir(), "out.env"))
+
+	secrets := map[string]interface{}{"DB_PASS": "it's a secret", "PORT": float64(5432)}
+	if err := renderDotenv(secrets); err != nil {
+		t.Fatalf("renderDotenv: %v", err)
+	}
+
+	data, err := os.ReadFile(outputName)
+	if err != nil {
+		t.Fatalf("reading rendered dotenv: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, `DB_PASS='it'\''s a secret'`) {
+		t.Errorf("dotenv output missing quoted DB_PASS line, got:\n%s", content)
+	}
+	if !strings.Contains(content, "PORT='5432'") {
+		t.Errorf("dotenv output missing PORT line, got:\n%s", content)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	withOutputName(t, filepath.Join(t.TempDir(), "out.json"))
+
+	if err := renderJSON(map[string]interface{}{"key": "value"}); err != nil {
+		t.Fatalf("renderJSON: %v", err)
+	}
+
+	data, err := os.ReadFile(outputName)
+	if err != nil {
+		t.Fatalf("reading rendered JSON: %v", err)
+	}
+	if !strings.Contains(string(data), `"key": "value"`) {
+		t.Errorf("JSON output missing expected key, got:\n%s", data)
+	}
+}
+
+func TestRenderK8sSecret(t *testing.T) {
+	withOutputName(t, filepath.Join(t.TempDir(), "out.yaml"))
+
+	prevName, prevNamespace := k8sSecretName, k8sSecretNamespace
+	k8sSecretName, k8sSecretNamespace = "my-secret", "my-namespace"
+	t.Cleanup(func() { k8sSecretName, k8sSecretNamespace = prevName, prevNamespace })
+
+	secrets := map[string]interface{}{"has:colon": "value"}
+	if err := renderK8sSecret(secrets); err != nil {
+		t.Fatalf("renderK8sSecret: %v", err)
+	}
+
+	data, err := os.ReadFile(outputName)
+	if err != nil {
+		t.Fatalf("reading rendered manifest: %v", err)
+	}
+	content := string(data)
+
+	if !strings.Contains(content, "name: my-secret") {
+		t.Errorf("manifest missing metadata.name, got:\n%s", content)
+	}
+	if !strings.Contains(content, `"has:colon": `+base64.StdEncoding.EncodeToString([]byte("value"))) {
+		t.Errorf("manifest missing quoted data key, got:\n%s", content)
+	}
+}
@@ -2,26 +2,23 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"html/template"
-	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	secretName        string
-	inputTemplateName string
-	outputName        string
-	region            string
-	cfgFile           string
+	inputTemplateName  string
+	outputName         string
+	outputFormat       string
+	k8sSecretName      string
+	k8sSecretNamespace string
+	region             string
+	cfgFile            string
 )
 
 func initConfig() {
@@ -46,10 +43,6 @@ func initConfig() {
 }
 
 func validateConfig() {
-	secretName = viper.GetString("aws-secret-name")
-	if len(secretName) == 0 {
-		logrus.Fatal("aws-secret-name not set (env: AWS_SECRET_NAME or flag)")
-	}
 	inputTemplateName = viper.GetString("application-config-file")
 	if len(inputTemplateName) == 0 {
 		logrus.Fatal("application-config-file not set (env: APPLICATION_CONFIG_FILE or flag)")
@@ -59,105 +52,57 @@ func validateConfig() {
 		logrus.Fatal("application-config-outfile not set (env: APPLICATION_CONFIG_OUTFILE or flag)")
 	}
 	region = viper.GetString("aws-region")
-	if len(region) == 0 {
+	if len(region) == 0 && backendsNeedAWSSession(parseSecretBackends(viper.GetString("backend"))) {
 		logrus.Fatal("aws-region not set (env: AWS_REGION or flag)")
 	}
-}
 
-func listSecretsWithFilter(name string, sess *session.Session) (map[string]string, error) {
-	// Use a context with timeout to make the list secrets request
-	duration := time.Now().Add(30 * time.Second)
-	ctx, cancel := context.WithDeadline(context.Background(), duration)
-	defer cancel()
-	// Create a new Secrets Manager client with the provided session
-	svc := secretsmanager.New(sess)
-
-	// Set up the input for the list secrets request with the specified name
-	input := &secretsmanager.ListSecretsInput{
-		Filters: []*secretsmanager.Filter{
-			{
-				Key:    aws.String("name"),
-				Values: []*string{aws.String(name)},
-			},
-		},
+	outputFormat = viper.GetString("format")
+	if len(outputFormat) == 0 {
+		outputFormat = "template"
 	}
-
-	result, err := svc.ListSecretsWithContext(ctx, input)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to list secrets from AWS")
-		return nil, err
-	}
-
-	// Build a map of secretName -> secretArn
-	secretArns := make(map[string]string)
-	for _, s := range result.SecretList {
-		secretArns[*s.Name] = *s.ARN
+	if outputFormat == formatK8sSecret {
+		k8sSecretName = viper.GetString("k8s-secret-name")
+		if len(k8sSecretName) == 0 {
+			logrus.Fatal("k8s-secret-name not set (env: K8S_SECRET_NAME or flag) and format=k8s-secret")
+		}
+		k8sSecretNamespace = viper.GetString("k8s-secret-namespace")
 	}
-	logrus.WithField("secrets", secretArns).Info("Secrets found")
+}
 
-	// Build the template context
-	templateContext := make(map[string]string)
-	for secretName, secretArn := range secretArns {
-		secretValue, err := getSecretValueWithContext(secretArn, svc, ctx)
+// buildSecretBackends reads the --backend/SECRET_BACKEND flag and
+// constructs each named backend in order, so resolveSecretContext can
+// stack them with later backends overriding earlier ones.
+func buildSecretBackends(sess *session.Session) ([]SecretProvider, error) {
+	var backends []SecretProvider
+	for _, name := range parseSecretBackends(viper.GetString("backend")) {
+		backend, err := newSecretProvider(name, sess)
 		if err != nil {
-			logrus.WithFields(logrus.Fields{"secretArn": secretArn, "secretName": secretName}).WithError(err).Error("Failed to get secret value")
 			return nil, err
 		}
-
-		// Try to parse as JSON and merge keys
-		var parsed map[string]interface{}
-		if err := json.Unmarshal([]byte(secretValue), &parsed); err == nil {
-			for k, v := range parsed {
-				templateContext[k] = fmt.Sprintf("%v", v)
-			}
-		} else {
-			templateContext[secretName] = secretValue
-		}
+		backends = append(backends, backend)
 	}
-	logrus.WithField("secrets value:", templateContext).Info("Secrets found")
-	return templateContext, nil
+	return backends, nil
 }
-func getSecretValueWithContext(secretArn string, svc *secretsmanager.SecretsManager, ctx context.Context) (string, error) {
-	// Set up the input for the get secret value request
-	input := &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(secretArn),
-	}
-
-	result, err := svc.GetSecretValueWithContext(ctx, input)
 
+// buildSecretSelector reads the secret-selection flags/env vars out of
+// viper and assembles the secretSelector passed to resolveSecretContext.
+func buildSecretSelector() secretSelector {
+	tags, err := parseSecretTags(viper.GetString("aws-secret-tag"))
 	if err != nil {
-		logrus.WithField("secretArn", secretArn).WithError(err).Fatal("Error when getting secret value")
+		logrus.WithError(err).Fatal("Invalid aws-secret-tag")
 	}
 
-	// Return the secret value as a string
-	return *result.SecretString, nil
-}
-
-func createFile(templateFile string, secrets map[string]string) {
-	// Parse the template file to create a new template
-	tmpl, err := template.ParseFiles(templateFile)
-
-	if err != nil {
-		logrus.WithField("templateFile", templateFile).WithError(err).Fatal("ParseFiles Error")
-	}
-
-	// Create a new output file to write the template output to
-	outputFile, err := os.Create(outputName)
-
-	if err != nil {
-		logrus.WithField("outputName", outputName).WithError(err).Fatal("Error creating output file")
+	selector := secretSelector{
+		Names:  parseSecretNames(viper.GetString("aws-secret-name")),
+		Prefix: viper.GetString("aws-secret-prefix"),
+		Tags:   tags,
 	}
 
-	defer outputFile.Close()
-
-	// Execute the template with the secrets map to create the output
-	err = tmpl.Execute(outputFile, secrets)
-
-	if err != nil {
-		logrus.WithField("outputName", outputName).WithError(err).Fatal("Error executing template")
+	if selector.empty() {
+		logrus.Fatal("no secret selector set: specify aws-secret-name, aws-secret-prefix, or aws-secret-tag (env: AWS_SECRET_NAME, AWS_SECRET_PREFIX, AWS_SECRET_TAG)")
 	}
 
-	logrus.Infof("Template output has been written to: %s", outputName)
+	return selector
 }
 
 func main() {
@@ -174,32 +119,88 @@ func main() {
 			if err != nil {
 				logrus.WithError(err).Fatal("Error creating AWS session")
 			}
-			secrets, err := listSecretsWithFilter(secretName, sess)
+			directives, err := parseSecretDirectives(inputTemplateName)
 			if err != nil {
-				logrus.WithError(err).Fatal("Error listing secrets with filter")
+				logrus.WithError(err).Fatal("Error parsing secret directives from template file")
+			}
+			selector := buildSecretSelector()
+			backends, err := buildSecretBackends(sess)
+			if err != nil {
+				logrus.WithError(err).Fatal("Error configuring secret backend(s)")
+			}
+
+			watch := viper.GetBool("watch")
+			onceThenWatch := viper.GetBool("once-then-watch")
+			if watch || onceThenWatch {
+				interval := viper.GetDuration("watch-interval")
+				if interval <= 0 {
+					interval = 60 * time.Second
+				}
+				err := runWatch(selector, directives, backends, sess, interval, viper.GetString("post-render-hook"), onceThenWatch)
+				if err != nil {
+					logrus.WithError(err).Fatal("Watch mode failed")
+				}
+				return
+			}
+
+			secrets, _, err := resolveSecretContext(context.Background(), backends, selector, directives)
+			if err != nil {
+				logrus.WithError(err).Fatal("Error resolving secrets")
+			}
+			if err := createFile(inputTemplateName, secrets); err != nil {
+				logrus.WithError(err).Fatal("Error creating output file")
 			}
-			createFile(inputTemplateName, secrets)
 		},
 	}
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is empty)")
-	rootCmd.Flags().String("aws-secret-name", "", "AWS secret tag value (env: AWS_SECRET_NAME)")
+	rootCmd.Flags().String("aws-secret-name", "", "Comma-separated AWS secret name(s) or ARN(s) (env: AWS_SECRET_NAME)")
+	rootCmd.Flags().String("aws-secret-prefix", "", "AWS secret name prefix to match (env: AWS_SECRET_PREFIX)")
+	rootCmd.Flags().String("aws-secret-tag", "", "Comma-separated tag filters, e.g. Env=prod,Team=platform (env: AWS_SECRET_TAG)")
 	rootCmd.Flags().String("application-config-file", "", "Input template file (env: APPLICATION_CONFIG_FILE)")
 	rootCmd.Flags().String("application-config-outfile", "", "Output file (env: APPLICATION_CONFIG_OUTFILE)")
 	rootCmd.Flags().String("aws-region", "", "AWS region (env: AWS_REGION)")
 	rootCmd.Flags().String("log-level", "info", "Log level (debug, info, warn, error, fatal) (env: LOG_LEVEL)")
+	rootCmd.Flags().Bool("watch", false, "Keep running and re-render when a watched secret rotates (env: WATCH)")
+	rootCmd.Flags().Bool("once-then-watch", false, "Render once, exiting non-zero if that fails, then enter watch mode (env: ONCE_THEN_WATCH)")
+	rootCmd.Flags().Duration("watch-interval", 60*time.Second, "Polling interval for secret rotation in watch mode (env: WATCH_INTERVAL)")
+	rootCmd.Flags().String("post-render-hook", "", "Shell command to run after each render in watch mode (env: POST_RENDER_HOOK)")
+	rootCmd.Flags().String("format", "template", "Output format: template, dotenv, json, toml, k8s-secret (env: OUTPUT_FORMAT)")
+	rootCmd.Flags().String("k8s-secret-name", "", "metadata.name to use for --format k8s-secret (env: K8S_SECRET_NAME)")
+	rootCmd.Flags().String("k8s-secret-namespace", "", "metadata.namespace to use for --format k8s-secret (env: K8S_SECRET_NAMESPACE)")
+	rootCmd.Flags().String("backend", "aws", "Comma-separated secret backend(s) to merge, in precedence order: aws, ssm, vault, pass (env: SECRET_BACKEND)")
 
 	viper.BindPFlag("log-level", rootCmd.Flags().Lookup("log-level"))
 	viper.BindPFlag("aws-secret-name", rootCmd.Flags().Lookup("aws-secret-name"))
+	viper.BindPFlag("aws-secret-prefix", rootCmd.Flags().Lookup("aws-secret-prefix"))
+	viper.BindPFlag("aws-secret-tag", rootCmd.Flags().Lookup("aws-secret-tag"))
 	viper.BindPFlag("application-config-file", rootCmd.Flags().Lookup("application-config-file"))
 	viper.BindPFlag("application-config-outfile", rootCmd.Flags().Lookup("application-config-outfile"))
 	viper.BindPFlag("aws-region", rootCmd.Flags().Lookup("aws-region"))
+	viper.BindPFlag("watch", rootCmd.Flags().Lookup("watch"))
+	viper.BindPFlag("once-then-watch", rootCmd.Flags().Lookup("once-then-watch"))
+	viper.BindPFlag("watch-interval", rootCmd.Flags().Lookup("watch-interval"))
+	viper.BindPFlag("post-render-hook", rootCmd.Flags().Lookup("post-render-hook"))
+	viper.BindPFlag("format", rootCmd.Flags().Lookup("format"))
+	viper.BindPFlag("k8s-secret-name", rootCmd.Flags().Lookup("k8s-secret-name"))
+	viper.BindPFlag("k8s-secret-namespace", rootCmd.Flags().Lookup("k8s-secret-namespace"))
+	viper.BindPFlag("backend", rootCmd.Flags().Lookup("backend"))
 
 	viper.BindEnv("log-level", "LOG_LEVEL")
 	viper.BindEnv("aws-secret-name", "AWS_SECRET_NAME")
+	viper.BindEnv("aws-secret-prefix", "AWS_SECRET_PREFIX")
+	viper.BindEnv("aws-secret-tag", "AWS_SECRET_TAG")
 	viper.BindEnv("application-config-file", "APPLICATION_CONFIG_FILE")
 	viper.BindEnv("application-config-outfile", "APPLICATION_CONFIG_OUTFILE")
 	viper.BindEnv("aws-region", "AWS_REGION")
+	viper.BindEnv("watch", "WATCH")
+	viper.BindEnv("once-then-watch", "ONCE_THEN_WATCH")
+	viper.BindEnv("watch-interval", "WATCH_INTERVAL")
+	viper.BindEnv("post-render-hook", "POST_RENDER_HOOK")
+	viper.BindEnv("format", "OUTPUT_FORMAT")
+	viper.BindEnv("k8s-secret-name", "K8S_SECRET_NAME")
+	viper.BindEnv("k8s-secret-namespace", "K8S_SECRET_NAMESPACE")
+	viper.BindEnv("backend", "SECRET_BACKEND")
 
 	if err := rootCmd.Execute(); err != nil {
 		logrus.WithError(err).Fatal("Command execution failed")
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// passProvider implements SecretProvider against the local `pass` (or
+// gopass, which is command-compatible) password store, for offline/dev
+// rendering. It shells out to the `pass` binary to read entries, so it
+// honors whatever GPG setup is already configured on the machine, but
+// lists entries by walking storeDir directly (see listByPrefix) rather
+// than parsing `pass find`'s human-oriented tree output.
+type passProvider struct {
+	binary   string
+	storeDir string
+}
+
+// newPassProvider resolves the password store directory from
+// PASSWORD_STORE_DIR, matching pass's own default of ~/.password-store
+// when unset.
+func newPassProvider() *passProvider {
+	storeDir := os.Getenv("PASSWORD_STORE_DIR")
+	if storeDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			storeDir = filepath.Join(home, ".password-store")
+		}
+	}
+	return &passProvider{binary: "pass", storeDir: storeDir}
+}
+
+func (p *passProvider) Name() string { return "pass" }
+
+// List treats selector.Names as entry names directly, and selector.Prefix
+// as a store subdirectory searched with `pass find`; selector.Tags has no
+// pass equivalent and is ignored.
+func (p *passProvider) List(ctx context.Context, selector secretSelector) ([]SecretRef, error) {
+	if selector.empty() {
+		return nil, fmt.Errorf("no secret selector set: specify aws-secret-name or aws-secret-prefix")
+	}
+
+	var refs []SecretRef
+	for _, name := range selector.Names {
+		refs = append(refs, SecretRef{Name: name, ID: name, Backend: p.Name()})
+	}
+
+	if selector.Prefix != "" {
+		entries, err := p.listByPrefix(ctx, selector.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, entries...)
+	}
+
+	logrus.WithField("entries", refs).Info("pass entries found")
+	return refs, nil
+}
+
+// listByPrefix walks storeDir/prefix on disk for *.gpg entries instead of
+// parsing `pass find`'s output, which is a human-oriented tree (box-drawing
+// characters, a "Search Terms:" header, indentation for subdirectories)
+// that was never meant to be machine-readable.
+func (p *passProvider) listByPrefix(ctx context.Context, prefix string) ([]SecretRef, error) {
+	if p.storeDir == "" {
+		return nil, fmt.Errorf("cannot determine password store directory: set PASSWORD_STORE_DIR")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	root := filepath.Join(p.storeDir, prefix)
+
+	var refs []SecretRef
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".gpg" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(p.storeDir, path)
+		if err != nil {
+			return err
+		}
+		entry := strings.TrimSuffix(filepath.ToSlash(rel), ".gpg")
+		name := strings.TrimPrefix(strings.TrimPrefix(entry, prefix), "/")
+		refs = append(refs, SecretRef{Name: name, ID: entry, Backend: p.Name()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pass entries under %q: %w", prefix, err)
+	}
+	return refs, nil
+}
+
+// Get returns the first line of `pass show <entry>`, which is the store's
+// convention for the primary secret value; directive.Stage and
+// directive.VersionID have no pass equivalent and are ignored since the
+// store has no concept of versioning.
+func (p *passProvider) Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error) {
+	out, err := p.run(ctx, "show", ref.ID)
+	if err != nil {
+		logrus.WithField("entry", ref.ID).WithError(err).Error("Error reading pass entry")
+		return "", err
+	}
+
+	value := strings.SplitN(out, "\n", 2)[0]
+	if directive != nil && directive.Length > 0 && len(value) != directive.Length {
+		return "", fmt.Errorf("secret %q: expected length %d, got %d", ref.Name, directive.Length, len(value))
+	}
+	return value, nil
+}
+
+func (p *passProvider) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %w: %s", p.binary, strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeFakeStoreEntry(t *testing.T, storeDir, entry string) {
+	t.Helper()
+	path := filepath.Join(storeDir, entry+".gpg")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating store dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("fake-gpg-blob"), 0o644); err != nil {
+		t.Fatalf("writing fake store entry: %v", err)
+	}
+}
+
+func TestPassProviderListByPrefix(t *testing.T) {
+	storeDir := t.TempDir()
+	writeFakeStoreEntry(t, storeDir, "prod/db_password")
+	writeFakeStoreEntry(t, storeDir, "prod/api_key")
+	writeFakeStoreEntry(t, storeDir, "prod/nested/inner")
+	writeFakeStoreEntry(t, storeDir, "staging/db_password")
+
+	p := &passProvider{binary: "pass", storeDir: storeDir}
+
+	refs, err := p.listByPrefix(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("listByPrefix: %v", err)
+	}
+
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+		if ref.Backend != "pass" {
+			t.Errorf("ref.Backend = %q, want pass", ref.Backend)
+		}
+		if ref.ID != "prod/"+ref.Name {
+			t.Errorf("ref.ID = %q, want prod/%s", ref.ID, ref.Name)
+		}
+	}
+	sort.Strings(names)
+
+	want := []string{"api_key", "db_password", "nested/inner"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("listByPrefix names = %v, want %v", names, want)
+	}
+}
+
+func TestPassProviderListByPrefixMissingDir(t *testing.T) {
+	storeDir := t.TempDir()
+	p := &passProvider{binary: "pass", storeDir: storeDir}
+
+	refs, err := p.listByPrefix(context.Background(), "nonexistent")
+	if err != nil {
+		t.Fatalf("listByPrefix on a missing subdirectory: unexpected error: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("listByPrefix on a missing subdirectory returned %d refs, want 0", len(refs))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/sirupsen/logrus"
+)
+
+// SecretRef identifies a single secret resolved by a SecretProvider. Name
+// is the template-context key to fall back to when the secret's value
+// isn't itself a JSON object; ID is the provider-specific handle (ARN, SSM
+// parameter path, Vault path, pass entry name) needed to fetch its value;
+// Backend records which provider resolved it.
+type SecretRef struct {
+	Name    string
+	ID      string
+	Backend string
+}
+
+// SecretProvider resolves a secretSelector to a set of SecretRefs and
+// fetches each one's value. Implementations exist for AWS Secrets Manager
+// (secrets.go), SSM Parameter Store (ssm.go), HashiCorp Vault (vault.go),
+// and the local pass store (pass.go).
+type SecretProvider interface {
+	// Name identifies the backend for --backend/SECRET_BACKEND and logs.
+	Name() string
+	// List resolves selector against this backend.
+	List(ctx context.Context, selector secretSelector) ([]SecretRef, error)
+	// Get fetches a single secret's raw value, honoring directive's
+	// version/stage pinning and length validation where the backend
+	// supports it.
+	Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error)
+}
+
+// newSecretProvider constructs the named backend. sess is reused for the
+// aws and ssm backends, which share credentials/region; vault and pass
+// read their own connection details from the environment
+// (VAULT_ADDR/VAULT_TOKEN, PASSWORD_STORE_DIR).
+func newSecretProvider(name string, sess *session.Session) (SecretProvider, error) {
+	switch name {
+	case "aws", "secretsmanager":
+		return newSecretsManagerProvider(sess), nil
+	case "ssm":
+		return newSSMProvider(sess), nil
+	case "vault":
+		return newVaultProvider()
+	case "pass":
+		return newPassProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown secret backend %q", name)
+	}
+}
+
+// parseSecretBackends splits the comma-separated --backend/SECRET_BACKEND
+// value into an ordered list of backend names, defaulting to the original
+// Secrets-Manager-only behavior when unset.
+func parseSecretBackends(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return []string{"aws"}
+	}
+	var backends []string
+	for _, b := range strings.Split(raw, ",") {
+		b = strings.TrimSpace(b)
+		if b != "" {
+			backends = append(backends, b)
+		}
+	}
+	return backends
+}
+
+// backendsNeedAWSSession reports whether any of the given backend names
+// needs an AWS session/region, so callers (namely validateConfig) know
+// whether to require --aws-region for a pure --backend vault or
+// --backend pass run, neither of which touches AWS at all.
+func backendsNeedAWSSession(backends []string) bool {
+	for _, name := range backends {
+		if name == "aws" || name == "secretsmanager" || name == "ssm" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSecretContext lists and fetches secrets across every given
+// backend and merges them into one template context. Backends are merged
+// in the order given, with a later backend's keys overriding an earlier
+// one's — e.g. --backend aws,vault lets Vault override same-named Secrets
+// Manager values. It also returns every resolved SecretRef keyed by name,
+// for callers (namely watch mode) that need to track individual secrets.
+func resolveSecretContext(ctx context.Context, backends []SecretProvider, selector secretSelector, directives map[string]*secretDirective) (map[string]interface{}, map[string]SecretRef, error) {
+	templateContext := make(map[string]interface{})
+	refsByName := make(map[string]SecretRef)
+
+	for _, backend := range backends {
+		refs, err := backend.List(ctx, selector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("backend %q: %w", backend.Name(), err)
+		}
+
+		values, err := fetchRefValues(ctx, backend, refs, directives)
+		if err != nil {
+			return nil, nil, fmt.Errorf("backend %q: %w", backend.Name(), err)
+		}
+
+		for k, v := range values {
+			templateContext[k] = v
+		}
+		for _, ref := range refs {
+			refsByName[ref.Name] = ref
+		}
+	}
+
+	return templateContext, refsByName, nil
+}
+
+// secretFetchWorkers bounds how many Get calls run concurrently against a
+// single backend when resolving a selector that matches many secrets.
+const secretFetchWorkers = 8
+
+// refFetchResult carries the outcome of resolving a single ref so it can
+// be merged back into the template context in a single goroutine.
+type refFetchResult struct {
+	name  string
+	value string
+	err   error
+}
+
+// fetchRefValues retrieves the value of each ref using a bounded pool of
+// secretFetchWorkers goroutines, then merges the results into the
+// template context: JSON objects are merged key-by-key preserving their
+// original structure, everything else falls back to the ref's own name
+// holding the raw string value.
+func fetchRefValues(ctx context.Context, backend SecretProvider, refs []SecretRef, directives map[string]*secretDirective) (map[string]interface{}, error) {
+	jobs := make(chan SecretRef, len(refs))
+	results := make(chan refFetchResult, len(refs))
+
+	var wg sync.WaitGroup
+	workers := secretFetchWorkers
+	if workers > len(refs) {
+		workers = len(refs)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				value, err := backend.Get(ctx, ref, directives[ref.Name])
+				results <- refFetchResult{name: ref.Name, value: value, err: err}
+			}
+		}()
+	}
+
+	for _, ref := range refs {
+		jobs <- ref
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	templateContext := make(map[string]interface{})
+	for res := range results {
+		if res.err != nil {
+			logrus.WithField("secretName", res.name).WithError(res.err).Error("Failed to get secret value")
+			return nil, res.err
+		}
+
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(res.value), &parsed); err == nil {
+			for k, v := range parsed {
+				templateContext[k] = v
+			}
+		} else {
+			templateContext[res.name] = res.value
+		}
+	}
+	return templateContext, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeProvider is a test double for SecretProvider: it serves refs/values
+// out of in-memory maps instead of calling out to AWS/Vault/pass, and
+// optionally counts concurrent Get calls so fetchRefValues' worker pool
+// can be exercised without mocking a real backend.
+type fakeProvider struct {
+	name string
+	refs []SecretRef
+	// values maps a ref's Name to either a plain string or a JSON object
+	// string, mirroring what a real backend's Get returns.
+	values map[string]string
+
+	concurrent  *int32
+	maxObserved *int32
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) List(ctx context.Context, selector secretSelector) ([]SecretRef, error) {
+	return p.refs, nil
+}
+
+func (p *fakeProvider) Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error) {
+	if p.concurrent != nil {
+		n := atomic.AddInt32(p.concurrent, 1)
+		defer atomic.AddInt32(p.concurrent, -1)
+		for {
+			max := atomic.LoadInt32(p.maxObserved)
+			if n <= max || atomic.CompareAndSwapInt32(p.maxObserved, max, n) {
+				break
+			}
+		}
+	}
+	value, ok := p.values[ref.Name]
+	if !ok {
+		return "", fmt.Errorf("fakeProvider %s: no value for %q", p.name, ref.Name)
+	}
+	return value, nil
+}
+
+func TestParseSecretBackends(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want []string
+	}{
+		{"", []string{"aws"}},
+		{"aws", []string{"aws"}},
+		{"aws,vault", []string{"aws", "vault"}},
+		{" aws , vault ,, pass ", []string{"aws", "vault", "pass"}},
+	}
+	for _, c := range cases {
+		got := parseSecretBackends(c.raw)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseSecretBackends(%q) = %v, want %v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestBackendsNeedAWSSession(t *testing.T) {
+	cases := []struct {
+		backends []string
+		want     bool
+	}{
+		{[]string{"aws"}, true},
+		{[]string{"ssm"}, true},
+		{[]string{"vault"}, false},
+		{[]string{"pass"}, false},
+		{[]string{"vault", "pass"}, false},
+		{[]string{"vault", "ssm"}, true},
+	}
+	for _, c := range cases {
+		if got := backendsNeedAWSSession(c.backends); got != c.want {
+			t.Errorf("backendsNeedAWSSession(%v) = %v, want %v", c.backends, got, c.want)
+		}
+	}
+}
+
+func TestNewSecretProviderUnknownBackend(t *testing.T) {
+	if _, err := newSecretProvider("bogus", nil); err == nil {
+		t.Error("expected an error for an unknown backend name")
+	}
+}
+
+func TestResolveSecretContextMergePrecedence(t *testing.T) {
+	aws := &fakeProvider{
+		name: "aws",
+		refs: []SecretRef{{Name: "db_password", ID: "db_password", Backend: "aws"}},
+		values: map[string]string{
+			"db_password": "aws-value",
+		},
+	}
+	vault := &fakeProvider{
+		name: "vault",
+		refs: []SecretRef{{Name: "db_password", ID: "db_password", Backend: "vault"}},
+		values: map[string]string{
+			"db_password": "vault-value",
+		},
+	}
+
+	context, refs, err := resolveSecretContext(context.Background(), []SecretProvider{aws, vault}, secretSelector{Names: []string{"db_password"}}, nil)
+	if err != nil {
+		t.Fatalf("resolveSecretContext: %v", err)
+	}
+
+	if context["db_password"] != "vault-value" {
+		t.Errorf("db_password = %v, want the later backend (vault)'s value", context["db_password"])
+	}
+	if refs["db_password"].Backend != "vault" {
+		t.Errorf("refs[db_password].Backend = %q, want vault", refs["db_password"].Backend)
+	}
+}
+
+func TestResolveSecretContextUnionsNonConflictingKeys(t *testing.T) {
+	aws := &fakeProvider{
+		name:   "aws",
+		refs:   []SecretRef{{Name: "db_password", ID: "db_password", Backend: "aws"}},
+		values: map[string]string{"db_password": "aws-value"},
+	}
+	pass := &fakeProvider{
+		name:   "pass",
+		refs:   []SecretRef{{Name: "api_key", ID: "api_key", Backend: "pass"}},
+		values: map[string]string{"api_key": "pass-value"},
+	}
+
+	context, _, err := resolveSecretContext(context.Background(), []SecretProvider{aws, pass}, secretSelector{Names: []string{"db_password", "api_key"}}, nil)
+	if err != nil {
+		t.Fatalf("resolveSecretContext: %v", err)
+	}
+
+	if context["db_password"] != "aws-value" || context["api_key"] != "pass-value" {
+		t.Errorf("context = %v, want both db_password and api_key present", context)
+	}
+}
+
+func TestFetchRefValuesMergesJSONObjects(t *testing.T) {
+	refs := []SecretRef{
+		{Name: "app_config", ID: "app_config", Backend: "fake"},
+		{Name: "plain_value", ID: "plain_value", Backend: "fake"},
+	}
+	provider := &fakeProvider{
+		name: "fake",
+		refs: refs,
+		values: map[string]string{
+			"app_config":  `{"host":"db.internal","port":5432}`,
+			"plain_value": "just-a-string",
+		},
+	}
+
+	got, err := fetchRefValues(context.Background(), provider, refs, nil)
+	if err != nil {
+		t.Fatalf("fetchRefValues: %v", err)
+	}
+
+	if got["host"] != "db.internal" || got["port"] != float64(5432) {
+		t.Errorf("expected app_config's JSON object fields merged in, got %v", got)
+	}
+	if got["plain_value"] != "just-a-string" {
+		t.Errorf("expected plain_value under its own name, got %v", got["plain_value"])
+	}
+	if _, ok := got["app_config"]; ok {
+		t.Error("app_config itself should not appear as a key once its JSON object is merged in")
+	}
+}
+
+func TestFetchRefValuesPropagatesError(t *testing.T) {
+	refs := []SecretRef{{Name: "missing", ID: "missing", Backend: "fake"}}
+	provider := &fakeProvider{name: "fake", refs: refs, values: map[string]string{}}
+
+	if _, err := fetchRefValues(context.Background(), provider, refs, nil); err == nil {
+		t.Error("expected an error when the backend fails to resolve a ref")
+	}
+}
+
+func TestFetchRefValuesBoundsConcurrency(t *testing.T) {
+	var refs []SecretRef
+	values := make(map[string]string)
+	for i := 0; i < secretFetchWorkers*4; i++ {
+		name := fmt.Sprintf("secret-%d", i)
+		refs = append(refs, SecretRef{Name: name, ID: name, Backend: "fake"})
+		values[name] = "value"
+	}
+
+	var concurrent, maxObserved int32
+	provider := &fakeProvider{name: "fake", refs: refs, values: values, concurrent: &concurrent, maxObserved: &maxObserved}
+
+	got, err := fetchRefValues(context.Background(), provider, refs, nil)
+	if err != nil {
+		t.Fatalf("fetchRefValues: %v", err)
+	}
+	if len(got) != len(refs) {
+		t.Errorf("len(got) = %d, want %d", len(got), len(refs))
+	}
+	if maxObserved > int32(secretFetchWorkers) {
+		t.Errorf("observed %d concurrent Get calls, want at most secretFetchWorkers (%d)", maxObserved, secretFetchWorkers)
+	}
+}
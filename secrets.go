@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/sirupsen/logrus"
+)
+
+// secretSelector describes the ways a caller can pick which secrets a
+// backend should resolve. At least one of Names, Prefix or Tags must be
+// set. Not every backend honors every field (e.g. Vault has no concept of
+// AWS tag filters); backends that can't satisfy a field should ignore it
+// rather than error, so a single selector can drive --backend aws,vault.
+type secretSelector struct {
+	// Names is a list of exact secret names or full ARNs to fetch.
+	Names []string
+	// Prefix matches secrets whose name starts with this value.
+	Prefix string
+	// Tags is a set of tag-key/tag-value pairs a secret must carry.
+	Tags map[string]string
+}
+
+func (s secretSelector) empty() bool {
+	return len(s.Names) == 0 && s.Prefix == "" && len(s.Tags) == 0
+}
+
+// parseSecretNames splits the comma-separated --aws-secret-name value into
+// individual secret names, trimming whitespace and dropping empty entries.
+func parseSecretNames(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var names []string
+	for _, n := range strings.Split(raw, ",") {
+		n = strings.TrimSpace(n)
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// parseSecretTags parses the comma-separated --aws-secret-tag value
+// ("Env=prod,Team=platform") into a tag-key/tag-value map.
+func parseSecretTags(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid aws-secret-tag entry %q, expected Key=Value", pair)
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return tags, nil
+}
+
+// secretARNPrefix identifies entries in selector.Names that are already
+// full secret ARNs rather than names, so they can be resolved directly
+// instead of through ListSecrets (whose "name" filter never matches an
+// ARN, since secret names don't start with "arn:").
+const secretARNPrefix = "arn:"
+
+// splitSecretNames separates a list of --aws-secret-name entries into ARNs
+// and plain names, since each is resolved through a different AWS call.
+func splitSecretNames(names []string) (arns, plain []string) {
+	for _, n := range names {
+		if strings.HasPrefix(n, secretARNPrefix) {
+			arns = append(arns, n)
+		} else {
+			plain = append(plain, n)
+		}
+	}
+	return arns, plain
+}
+
+// secretsManagerProvider implements SecretProvider against AWS Secrets
+// Manager. It's the tool's original and default backend.
+type secretsManagerProvider struct {
+	svc *secretsmanager.SecretsManager
+}
+
+func newSecretsManagerProvider(sess *session.Session) *secretsManagerProvider {
+	return &secretsManagerProvider{svc: secretsmanager.New(sess)}
+}
+
+func (p *secretsManagerProvider) Name() string { return "aws" }
+
+// List resolves each of Names/Prefix/Tags independently and unions the
+// results, the same OR semantics every other backend (ssm.go, vault.go,
+// pass.go) uses: selector.Names that look like ARNs are resolved directly
+// via DescribeSecret, plain Names via ListSecrets post-filtered to an exact
+// name match (ListSecrets' own "name" filter is a prefix match, which would
+// otherwise silently pull in e.g. "foobar" for a request for "foo"), Prefix
+// via ListSecrets' own prefix match, and Tags via ListSecrets' tag-key
+// filter narrowed further by an exact client-side match of every pair.
+func (p *secretsManagerProvider) List(ctx context.Context, selector secretSelector) ([]SecretRef, error) {
+	if selector.empty() {
+		return nil, fmt.Errorf("no secret selector set: specify aws-secret-name, aws-secret-prefix, or aws-secret-tag")
+	}
+
+	var refs []SecretRef
+
+	arns, names := splitSecretNames(selector.Names)
+
+	if len(arns) > 0 {
+		arnRefs, err := p.listByARNs(ctx, arns)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, arnRefs...)
+	}
+
+	if len(names) > 0 {
+		nameRefs, err := p.listByNames(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, nameRefs...)
+	}
+
+	if selector.Prefix != "" {
+		prefixRefs, err := p.listByPrefix(ctx, selector.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, prefixRefs...)
+	}
+
+	if len(selector.Tags) > 0 {
+		tagRefs, err := p.listByTags(ctx, selector.Tags)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, tagRefs...)
+	}
+
+	logrus.WithField("secrets", refs).Info("Secrets found")
+	return refs, nil
+}
+
+// listByARNs resolves each ARN directly via DescribeSecret, which also
+// confirms the secret exists and recovers its canonical Name for the
+// template context key.
+func (p *secretsManagerProvider) listByARNs(ctx context.Context, arns []string) ([]SecretRef, error) {
+	var refs []SecretRef
+	for _, arn := range arns {
+		result, err := p.svc.DescribeSecretWithContext(ctx, &secretsmanager.DescribeSecretInput{
+			SecretId: aws.String(arn),
+		})
+		if err != nil {
+			logrus.WithField("secretArn", arn).WithError(err).Error("Failed to describe secret from AWS")
+			return nil, err
+		}
+		refs = append(refs, SecretRef{Name: aws.StringValue(result.Name), ID: arn, Backend: p.Name()})
+	}
+	return refs, nil
+}
+
+// listByNames looks up plain secret names via ListSecrets' "name" filter,
+// then keeps only results whose Name exactly equals one of names, since
+// the filter itself only guarantees a prefix match.
+func (p *secretsManagerProvider) listByNames(ctx context.Context, names []string) ([]SecretRef, error) {
+	values := make([]*string, 0, len(names))
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		values = append(values, aws.String(n))
+		wanted[n] = true
+	}
+
+	input := &secretsmanager.ListSecretsInput{
+		Filters: []*secretsmanager.Filter{{Key: aws.String("name"), Values: values}},
+	}
+
+	listed, err := p.listAll(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []SecretRef
+	for _, ref := range listed {
+		if wanted[ref.Name] {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// listByPrefix resolves secrets whose name starts with prefix via
+// ListSecrets' own "name" filter, which is a genuine prefix match.
+func (p *secretsManagerProvider) listByPrefix(ctx context.Context, prefix string) ([]SecretRef, error) {
+	input := &secretsmanager.ListSecretsInput{
+		Filters: []*secretsmanager.Filter{{Key: aws.String("name"), Values: []*string{aws.String(prefix)}}},
+	}
+	return p.listAll(ctx, input)
+}
+
+// listByTags resolves secrets carrying every given tag-key/tag-value pair.
+// ListSecrets' own "tag-key"/"tag-value" filters aren't correlated: AWS ORs
+// within a filter's Values and ANDs only across filter keys, so passing
+// tag-key/tag-value filters for multiple pairs would match a secret tagged
+// Env=dev,Team=platform against a request for Env=prod,Team=platform. The
+// tag-key filter is still used to narrow the candidate set (a genuine OR,
+// which is what's wanted there), but the actual Env=prod AND Team=platform
+// match is done client-side against each candidate's own Tags.
+func (p *secretsManagerProvider) listByTags(ctx context.Context, tags map[string]string) ([]SecretRef, error) {
+	keys := make([]*string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, aws.String(key))
+	}
+
+	input := &secretsmanager.ListSecretsInput{
+		Filters: []*secretsmanager.Filter{{Key: aws.String("tag-key"), Values: keys}},
+	}
+
+	entries, err := p.listEntries(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []SecretRef
+	for _, entry := range entries {
+		if entryHasAllTags(entry, tags) {
+			refs = append(refs, SecretRef{Name: *entry.Name, ID: *entry.ARN, Backend: p.Name()})
+		}
+	}
+	return refs, nil
+}
+
+// entryHasAllTags reports whether entry carries every key=value pair in
+// tags among its own Tags, i.e. the AND-of-pairs match ListSecrets'
+// Filters can't express.
+func entryHasAllTags(entry *secretsmanager.SecretListEntry, tags map[string]string) bool {
+	entryTags := make(map[string]string, len(entry.Tags))
+	for _, t := range entry.Tags {
+		entryTags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	for key, value := range tags {
+		if entryTags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// listAll pages through ListSecretsWithContext via NextToken and converts
+// the full result set matching input into SecretRefs.
+func (p *secretsManagerProvider) listAll(ctx context.Context, input *secretsmanager.ListSecretsInput) ([]SecretRef, error) {
+	entries, err := p.listEntries(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]SecretRef, 0, len(entries))
+	for _, entry := range entries {
+		refs = append(refs, SecretRef{Name: *entry.Name, ID: *entry.ARN, Backend: p.Name()})
+	}
+	return refs, nil
+}
+
+// listEntries pages through ListSecretsWithContext via NextToken until the
+// full set of raw SecretListEntry results matching input has been
+// collected, preserving fields (like Tags) that SecretRef doesn't carry.
+func (p *secretsManagerProvider) listEntries(ctx context.Context, input *secretsmanager.ListSecretsInput) ([]*secretsmanager.SecretListEntry, error) {
+	var entries []*secretsmanager.SecretListEntry
+	for {
+		result, err := p.svc.ListSecretsWithContext(ctx, input)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to list secrets from AWS")
+			return nil, err
+		}
+		entries = append(entries, result.SecretList...)
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	return entries, nil
+}
+
+// Get fetches a secret's value, honoring the version pinning requested by
+// directive (VersionId takes precedence over VersionStage), and enforces
+// directive.Length when set.
+func (p *secretsManagerProvider) Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(ref.ID),
+	}
+	if directive != nil {
+		switch {
+		case directive.VersionID != "":
+			input.VersionId = aws.String(directive.VersionID)
+		case directive.Stage != "":
+			input.VersionStage = aws.String(directive.Stage)
+		}
+	}
+
+	result, err := p.svc.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		logrus.WithField("secretArn", ref.ID).WithError(err).Error("Error when getting secret value")
+		return "", err
+	}
+
+	value := *result.SecretString
+	if directive != nil && directive.Length > 0 && len(value) != directive.Length {
+		return "", fmt.Errorf("secret %q: expected length %d, got %d", ref.Name, directive.Length, len(value))
+	}
+
+	return value, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func TestParseSecretNames(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{"foo", []string{"foo"}},
+		{"foo,bar", []string{"foo", "bar"}},
+		{" foo , bar ,, ", []string{"foo", "bar"}},
+	}
+	for _, c := range cases {
+		got := parseSecretNames(c.raw)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseSecretNames(%q) = %v, want %v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestParseSecretTags(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"Env=prod", map[string]string{"Env": "prod"}, false},
+		{"Env=prod,Team=platform", map[string]string{"Env": "prod", "Team": "platform"}, false},
+		{" Env = prod ", map[string]string{"Env ": " prod"}, false},
+		{"Env", nil, true},
+		{"=prod", nil, true},
+	}
+	for _, c := range cases {
+		got, err := parseSecretTags(c.raw)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseSecretTags(%q): expected error, got none", c.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSecretTags(%q): unexpected error: %v", c.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseSecretTags(%q) = %v, want %v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestSplitSecretNames(t *testing.T) {
+	arns, plain := splitSecretNames([]string{
+		"arn:aws:secretsmanager:us-east-1:111122223333:secret:foo-AbCdEf",
+		"bar",
+		"arn:aws:secretsmanager:us-east-1:111122223333:secret:baz-GhIjKl",
+	})
+	wantARNs := []string{
+		"arn:aws:secretsmanager:us-east-1:111122223333:secret:foo-AbCdEf",
+		"arn:aws:secretsmanager:us-east-1:111122223333:secret:baz-GhIjKl",
+	}
+	wantPlain := []string{"bar"}
+	if !reflect.DeepEqual(arns, wantARNs) {
+		t.Errorf("splitSecretNames arns = %v, want %v", arns, wantARNs)
+	}
+	if !reflect.DeepEqual(plain, wantPlain) {
+		t.Errorf("splitSecretNames plain = %v, want %v", plain, wantPlain)
+	}
+}
+
+func TestEntryHasAllTags(t *testing.T) {
+	entry := &secretsmanager.SecretListEntry{
+		Tags: []*secretsmanager.Tag{
+			{Key: aws.String("Env"), Value: aws.String("prod")},
+			{Key: aws.String("Team"), Value: aws.String("platform")},
+		},
+	}
+
+	// Every requested pair matches exactly.
+	if !entryHasAllTags(entry, map[string]string{"Env": "prod", "Team": "platform"}) {
+		t.Error("expected entry to match Env=prod,Team=platform")
+	}
+
+	// A single matching pair among others the entry also carries still
+	// matches: Tags is a carries-at-least-these-pairs check.
+	if !entryHasAllTags(entry, map[string]string{"Env": "prod"}) {
+		t.Error("expected entry to match Env=prod alone")
+	}
+
+	// The bug this guards against: a filter-level OR-across-pairs match
+	// (entry has *a* key in {Env,Team} and *a* value in {dev,platform})
+	// must not be treated as a match when the pairs aren't correlated.
+	if entryHasAllTags(entry, map[string]string{"Env": "dev", "Team": "platform"}) {
+		t.Error("entry tagged Env=prod,Team=platform must not match a request for Env=dev,Team=platform")
+	}
+
+	// Missing key entirely.
+	if entryHasAllTags(entry, map[string]string{"Region": "us-east-1"}) {
+		t.Error("entry without a Region tag must not match a request for Region=us-east-1")
+	}
+}
+
+func TestSecretSelectorEmpty(t *testing.T) {
+	if !(secretSelector{}).empty() {
+		t.Error("zero-value secretSelector should be empty")
+	}
+	if (secretSelector{Names: []string{"foo"}}).empty() {
+		t.Error("selector with Names should not be empty")
+	}
+	if (secretSelector{Prefix: "foo"}).empty() {
+		t.Error("selector with Prefix should not be empty")
+	}
+	if (secretSelector{Tags: map[string]string{"a": "b"}}).empty() {
+		t.Error("selector with Tags should not be empty")
+	}
+}
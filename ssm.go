@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/sirupsen/logrus"
+)
+
+// ssmProvider implements SecretProvider against AWS Systems Manager
+// Parameter Store, for teams that keep config there instead of (or
+// alongside) Secrets Manager.
+type ssmProvider struct {
+	svc *ssm.SSM
+}
+
+func newSSMProvider(sess *session.Session) *ssmProvider {
+	return &ssmProvider{svc: ssm.New(sess)}
+}
+
+func (p *ssmProvider) Name() string { return "ssm" }
+
+// List resolves selector.Prefix via GetParametersByPath (recursive,
+// decrypted), selector.Names via direct parameter names, and
+// selector.Tags via a DescribeParameters tag filter.
+func (p *ssmProvider) List(ctx context.Context, selector secretSelector) ([]SecretRef, error) {
+	if selector.empty() {
+		return nil, fmt.Errorf("no secret selector set: specify aws-secret-name, aws-secret-prefix, or aws-secret-tag")
+	}
+
+	var refs []SecretRef
+
+	if selector.Prefix != "" {
+		pathRefs, err := p.listByPath(ctx, selector.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, pathRefs...)
+	}
+
+	for _, name := range selector.Names {
+		refs = append(refs, SecretRef{Name: name, ID: name, Backend: p.Name()})
+	}
+
+	if len(selector.Tags) > 0 {
+		tagRefs, err := p.listByTags(ctx, selector.Tags)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, tagRefs...)
+	}
+
+	logrus.WithField("parameters", refs).Info("SSM parameters found")
+	return refs, nil
+}
+
+func (p *ssmProvider) listByPath(ctx context.Context, path string) ([]SecretRef, error) {
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+
+	var refs []SecretRef
+	for {
+		result, err := p.svc.GetParametersByPathWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range result.Parameters {
+			refs = append(refs, SecretRef{Name: *param.Name, ID: *param.Name, Backend: p.Name()})
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	return refs, nil
+}
+
+func (p *ssmProvider) listByTags(ctx context.Context, tags map[string]string) ([]SecretRef, error) {
+	var filters []*ssm.ParameterStringFilter
+	for key, value := range tags {
+		filters = append(filters, &ssm.ParameterStringFilter{
+			Key:    aws.String("tag:" + key),
+			Option: aws.String("Equals"),
+			Values: []*string{aws.String(value)},
+		})
+	}
+
+	input := &ssm.DescribeParametersInput{ParameterFilters: filters}
+
+	var refs []SecretRef
+	for {
+		result, err := p.svc.DescribeParametersWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range result.Parameters {
+			refs = append(refs, SecretRef{Name: *meta.Name, ID: *meta.Name, Backend: p.Name()})
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	return refs, nil
+}
+
+// Get fetches a single parameter's decrypted value. SSM addresses a
+// specific version as "name:version" in GetParameter, which
+// directive.VersionID maps onto; directive.Stage has no SSM equivalent
+// and is ignored.
+func (p *ssmProvider) Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error) {
+	name := ref.ID
+	if directive != nil && directive.VersionID != "" {
+		name = fmt.Sprintf("%s:%s", ref.ID, directive.VersionID)
+	}
+
+	result, err := p.svc.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		logrus.WithField("parameter", name).WithError(err).Error("Error when getting SSM parameter value")
+		return "", err
+	}
+
+	value := *result.Parameter.Value
+	if directive != nil && directive.Length > 0 && len(value) != directive.Length {
+		return "", fmt.Errorf("parameter %q: expected length %d, got %d", ref.Name, directive.Length, len(value))
+	}
+	return value, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// templateFuncMap builds the function map available to input templates:
+// Sprig's common helpers (default, quote, indent, b64enc, b64dec, toJson,
+// fromJson, ...) plus toYaml/lookup/required, which Sprig doesn't provide.
+// It overrides Sprig's `required` with one that checks for a dotted-path
+// key in the secret context, since that's the missing-value case templates
+// here actually need to guard against.
+func templateFuncMap(context map[string]interface{}) template.FuncMap {
+	funcMap := sprig.TxtFuncMap()
+
+	funcMap["toYaml"] = func(value interface{}) (string, error) {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	}
+	funcMap["lookup"] = func(path string) (interface{}, error) {
+		return lookupPath(context, path)
+	}
+	funcMap["required"] = func(path string) (interface{}, error) {
+		value, err := lookupPath(context, path)
+		if err != nil {
+			return nil, fmt.Errorf("required template value %q is missing: %w", path, err)
+		}
+		return value, nil
+	}
+
+	return funcMap
+}
+
+// lookupPath resolves a dotted path ("db.credentials.password") into
+// nested map[string]interface{} values within context.
+func lookupPath(context map[string]interface{}, path string) (interface{}, error) {
+	var current interface{} = context
+	for _, segment := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot descend into %q: not an object", segment)
+		}
+		value, ok := m[segment]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", segment)
+		}
+		current = value
+	}
+	return current, nil
+}
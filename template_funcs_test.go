@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestLookupPath(t *testing.T) {
+	context := map[string]interface{}{
+		"db": map[string]interface{}{
+			"credentials": map[string]interface{}{
+				"password": "hunter2",
+			},
+		},
+	}
+
+	got, err := lookupPath(context, "db.credentials.password")
+	if err != nil {
+		t.Fatalf("lookupPath: unexpected error: %v", err)
+	}
+	if got != "hunter2" {
+		t.Errorf("lookupPath() = %v, want hunter2", got)
+	}
+
+	if _, err := lookupPath(context, "db.missing"); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+	if _, err := lookupPath(context, "db.credentials.password.extra"); err == nil {
+		t.Error("expected an error descending into a non-object value")
+	}
+}
+
+func TestTemplateFuncMapRequired(t *testing.T) {
+	context := map[string]interface{}{"present": "value"}
+	funcMap := templateFuncMap(context)
+
+	tmpl := template.Must(template.New("t").Funcs(funcMap).Parse(`{{ required "present" }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, context); err != nil {
+		t.Fatalf("executing template with a present key: %v", err)
+	}
+	if buf.String() != "value" {
+		t.Errorf("rendered %q, want %q", buf.String(), "value")
+	}
+
+	missingTmpl := template.Must(template.New("t").Funcs(funcMap).Parse(`{{ required "missing" }}`))
+	if err := missingTmpl.Execute(&bytes.Buffer{}, context); err == nil {
+		t.Error("expected an error executing a template that requires a missing key")
+	}
+}
+
+func TestTemplateFuncMapToYaml(t *testing.T) {
+	funcMap := templateFuncMap(nil)
+	tmpl := template.Must(template.New("t").Funcs(funcMap).Parse(`{{ toYaml . }}`))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"a": "b"}); err != nil {
+		t.Fatalf("executing toYaml template: %v", err)
+	}
+	if buf.String() != "a: b" {
+		t.Errorf("rendered %q, want %q", buf.String(), "a: b")
+	}
+}
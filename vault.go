@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/sirupsen/logrus"
+)
+
+// vaultProvider implements SecretProvider against a HashiCorp Vault KV v2
+// secrets engine, authenticated via VAULT_ADDR/VAULT_TOKEN (or AppRole
+// via VAULT_ROLE_ID/VAULT_SECRET_ID) in the environment.
+type vaultProvider struct {
+	client *vault.Client
+	mount  string
+}
+
+// newVaultProvider builds a Vault client from the standard VAULT_ADDR
+// environment variable, authenticating with VAULT_TOKEN if set or falling
+// back to an AppRole login via VAULT_ROLE_ID/VAULT_SECRET_ID. The KV v2
+// mount defaults to "secret", overridable with VAULT_KV_MOUNT.
+func newVaultProvider() (*vaultProvider, error) {
+	config := vault.DefaultConfig()
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vault client: %w", err)
+	}
+
+	switch {
+	case os.Getenv("VAULT_TOKEN") != "":
+		client.SetToken(os.Getenv("VAULT_TOKEN"))
+	case os.Getenv("VAULT_ROLE_ID") != "" && os.Getenv("VAULT_SECRET_ID") != "":
+		secret, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
+			"role_id":   os.Getenv("VAULT_ROLE_ID"),
+			"secret_id": os.Getenv("VAULT_SECRET_ID"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("AppRole login: %w", err)
+		}
+		if secret == nil || secret.Auth == nil {
+			return nil, fmt.Errorf("AppRole login returned no auth info")
+		}
+		client.SetToken(secret.Auth.ClientToken)
+	default:
+		return nil, fmt.Errorf("no Vault credentials: set VAULT_TOKEN or VAULT_ROLE_ID/VAULT_SECRET_ID")
+	}
+
+	mount := os.Getenv("VAULT_KV_MOUNT")
+	if mount == "" {
+		mount = "secret"
+	}
+
+	return &vaultProvider{client: client, mount: mount}, nil
+}
+
+func (p *vaultProvider) Name() string { return "vault" }
+
+// List treats selector.Names/selector.Prefix as KV v2 paths under the
+// configured mount (selector.Tags has no Vault KV equivalent and is
+// ignored): each Name is used directly, and Prefix is recursively listed.
+func (p *vaultProvider) List(ctx context.Context, selector secretSelector) ([]SecretRef, error) {
+	if selector.empty() {
+		return nil, fmt.Errorf("no secret selector set: specify aws-secret-name or aws-secret-prefix")
+	}
+
+	var refs []SecretRef
+	for _, name := range selector.Names {
+		refs = append(refs, SecretRef{Name: name, ID: name, Backend: p.Name()})
+	}
+
+	if selector.Prefix != "" {
+		prefixRefs, err := p.listByPrefix(ctx, selector.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, prefixRefs...)
+	}
+
+	logrus.WithField("paths", refs).Info("Vault secrets found")
+	return refs, nil
+}
+
+func (p *vaultProvider) listByPrefix(ctx context.Context, prefix string) ([]SecretRef, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	listPath := fmt.Sprintf("%s/metadata/%s", p.mount, prefix)
+
+	secret, err := p.client.Logical().ListWithContext(ctx, listPath)
+	if err != nil {
+		return nil, fmt.Errorf("listing %q: %w", listPath, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var refs []SecretRef
+	for _, k := range keys {
+		key, ok := k.(string)
+		if !ok || strings.HasSuffix(key, "/") {
+			continue // nested "directory", not a leaf secret
+		}
+		refs = append(refs, SecretRef{Name: key, ID: prefix + "/" + key, Backend: p.Name()})
+	}
+	return refs, nil
+}
+
+// Get reads a KV v2 secret's data map and re-marshals it to JSON so the
+// caller's usual JSON-object merge logic picks up its keys; directive's
+// VersionID, if set, selects a specific KV v2 version, and Length is
+// checked against the re-marshaled JSON. directive.Stage has no KV v2
+// equivalent and is ignored.
+func (p *vaultProvider) Get(ctx context.Context, ref SecretRef, directive *secretDirective) (string, error) {
+	readPath := fmt.Sprintf("%s/data/%s", p.mount, ref.ID)
+	params := map[string][]string{}
+	if directive != nil && directive.VersionID != "" {
+		params["version"] = []string{directive.VersionID}
+	}
+
+	secret, err := p.client.Logical().ReadWithDataWithContext(ctx, readPath, params)
+	if err != nil {
+		logrus.WithField("path", readPath).WithError(err).Error("Error reading Vault secret")
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("no data at Vault path %q", readPath)
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected KV v2 response shape at %q", readPath)
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("marshaling Vault secret data at %q: %w", readPath, err)
+	}
+	value := string(encoded)
+
+	if directive != nil && directive.Length > 0 && len(value) != directive.Length {
+		return "", fmt.Errorf("secret %q: expected length %d, got %d", ref.Name, directive.Length, len(value))
+	}
+	return value, nil
+}